@@ -0,0 +1,162 @@
+// Program wavy generates images of waveforms for documentation
+// purposes. Output defaults to PNG, but SVG and PDF are also
+// supported, either via -format or by naming *out with a ".svg" or
+// ".pdf" extension.
+//
+// The input file is, by default, a plain text file in wvy format:
+//
+// <chars><space><signal><space><commad-values>
+// <blank lines = half line skip>
+//
+// A WaveDrom WaveJSON file is also accepted, either via
+// -informat=wavejson or by naming *in with a ".json" or ".wavejson"
+// extension.
+//
+// The size of the generated image is the smallest size that can render
+// the image.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/llgcode/draw2d"
+	"github.com/llgcode/draw2d/draw2dimg"
+	"github.com/llgcode/draw2d/draw2dpdf"
+	"github.com/llgcode/draw2d/draw2dsvg"
+
+	"github.com/tinkerator/wavy/pkg/wavy"
+)
+
+var (
+	in       = flag.String("input", "", "input file in wvy or wavejson format")
+	informat = flag.String("informat", "", "input format: wvy or wavejson (default inferred from -input extension)")
+	out      = flag.String("output", "", "output file")
+	format   = flag.String("format", "", "output format: png, svg or pdf (default inferred from -output extension)")
+	fSize    = flag.Float64("fs", 16, "font size")
+	debug    = flag.Bool("debug", false, "use to generate vertical lines")
+	radix    = flag.String("radix", "hex", "bus signal label radix: bin, oct, dec or hex")
+)
+
+// inputFormat resolves the requested input format, falling back to
+// the extension of *in when -informat is not given.
+func inputFormat() string {
+	if *informat != "" {
+		return *informat
+	}
+	if i := strings.LastIndex(*in, "."); i >= 0 {
+		switch ext := (*in)[i+1:]; ext {
+		case "wavejson", "json":
+			return "wavejson"
+		}
+	}
+	return "wvy"
+}
+
+// renderer bundles the draw2d graphic context for a particular
+// output backend together with the logic needed to persist its
+// contents to *out once the drawing is complete.
+type renderer struct {
+	gc   draw2d.GraphicContext
+	save func() error
+}
+
+// outputFormat resolves the requested output format, falling back
+// to the extension of *out when -format is not given.
+func outputFormat() string {
+	if *format != "" {
+		return *format
+	}
+	if i := strings.LastIndex(*out, "."); i >= 0 {
+		switch ext := (*out)[i+1:]; ext {
+		case "svg", "pdf", "png":
+			return ext
+		}
+	}
+	return "png"
+}
+
+// newRenderer constructs the renderer for f, sized to hold a
+// wide x high drawing.
+func newRenderer(f string, wide, high int) (*renderer, error) {
+	switch f {
+	case "", "png":
+		dest := image.NewRGBA(image.Rect(0, 0, wide, high))
+		return &renderer{
+			gc: draw2dimg.NewGraphicContext(dest),
+			save: func() error {
+				return draw2dimg.SaveToPngFile(*out, dest)
+			},
+		}, nil
+	case "svg":
+		svg := draw2dsvg.NewSvg()
+		svg.Width = fmt.Sprintf("%dpx", wide)
+		svg.Height = fmt.Sprintf("%dpx", high)
+		return &renderer{
+			gc: draw2dsvg.NewGraphicContext(svg),
+			save: func() error {
+				return draw2dsvg.SaveToSvgFile(*out, svg)
+			},
+		}, nil
+	case "pdf":
+		fontDir, err := wavy.PDFFontDir()
+		if err != nil {
+			return nil, fmt.Errorf("preparing pdf font: %v", err)
+		}
+		pdf := gofpdf.NewCustom(&gofpdf.InitType{
+			UnitStr:    "pt",
+			Size:       gofpdf.SizeType{Wd: float64(wide), Ht: float64(high)},
+			FontDirStr: fontDir,
+		})
+		pdf.AddPage()
+		return &renderer{
+			gc: draw2dpdf.NewGraphicContext(pdf),
+			save: func() error {
+				return draw2dpdf.SaveToPdfFile(*out, pdf)
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported -format %q", f)
+}
+
+func main() {
+	flag.Parse()
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatalf("failed to read %q: %v", *in, err)
+	}
+	defer f.Close()
+
+	var d *wavy.Diagram
+	if inputFormat() == "wavejson" {
+		d, err = wavy.ParseWaveJSON(f)
+	} else {
+		d, err = wavy.Parse(f)
+	}
+	if err != nil {
+		log.Fatalf("%s: %v", *in, err)
+	}
+	d.FontSize = *fSize
+	d.Debug = *debug
+	d.Radix = *radix
+
+	b := d.Bounds()
+	r, err := newRenderer(outputFormat(), b.Dx(), b.Dy())
+	if err != nil {
+		log.Fatalf("failed to set up %q: %v", *out, err)
+	}
+
+	if err := d.RenderGC(r.gc); err != nil {
+		log.Fatalf("failed to render %q: %v", *in, err)
+	}
+
+	if err := r.save(); err != nil {
+		log.Fatalf("error saving to %q: %v", *out, err)
+	}
+}
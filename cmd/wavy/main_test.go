@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tinkerator/wavy/pkg/wavy"
+)
+
+const sampleWvy = "x<--->x<--->x name =0xFF,0x1\n"
+
+// renderSample parses sampleWvy and renders it with format,
+// returning the path of the file it wrote under dir.
+func renderSample(t *testing.T, dir, format string) string {
+	t.Helper()
+	d, err := wavy.Parse(strings.NewReader(sampleWvy))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	path := filepath.Join(dir, "out."+format)
+	saved := *out
+	*out = path
+	defer func() { *out = saved }()
+
+	b := d.Bounds()
+	r, err := newRenderer(format, b.Dx(), b.Dy())
+	if err != nil {
+		t.Fatalf("newRenderer(%q): %v", format, err)
+	}
+	if err := d.RenderGC(r.gc); err != nil {
+		t.Fatalf("RenderGC: %v", err)
+	}
+	if err := r.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	return path
+}
+
+// TestRenderBackendsProduceWellFormedFiles checks that each output
+// backend writes a file of the shape its format requires, catching
+// regressions like a pdf backend that writes a broken or empty
+// document.
+func TestRenderBackendsProduceWellFormedFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("png", func(t *testing.T) {
+		f, err := os.Open(renderSample(t, dir, "png"))
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		defer f.Close()
+		if _, _, err := image.Decode(f); err != nil {
+			t.Fatalf("not a valid PNG: %v", err)
+		}
+	})
+
+	t.Run("svg", func(t *testing.T) {
+		b, err := os.ReadFile(renderSample(t, dir, "svg"))
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		var root struct {
+			XMLName xml.Name `xml:"svg"`
+		}
+		if err := xml.Unmarshal(b, &root); err != nil {
+			t.Fatalf("not well-formed SVG: %v", err)
+		}
+	})
+
+	t.Run("pdf", func(t *testing.T) {
+		b, err := os.ReadFile(renderSample(t, dir, "pdf"))
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if !bytes.HasPrefix(b, []byte("%PDF-")) {
+			t.Fatalf("missing %%PDF- header")
+		}
+		if !bytes.Contains(b, []byte("%%EOF")) {
+			t.Fatalf("missing %%%%EOF trailer")
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package wavy
+
+import (
+	"image"
+	"strings"
+	"testing"
+
+	"github.com/llgcode/draw2d/draw2dimg"
+)
+
+// TestMultAnalogWidth checks that an analog row's width comes from
+// its sample count, with no step requirement of its own (it isn't
+// rendered through the digital box/level transition switch).
+func TestMultAnalogWidth(t *testing.T) {
+	sig := signal{name: "vout", analog: true, samples: []float64{0.1, 2.5, 3.3, 0.4}}
+	step, width := mult(sig)
+	if step != 0 {
+		t.Errorf("step = %d, want 0", step)
+	}
+	if width != len(sig.samples) {
+		t.Errorf("width = %d, want %d", width, len(sig.samples))
+	}
+}
+
+// TestRenderAnalogRow checks that a Diagram with an analog row
+// renders without error, exercising drawAnalog's sample-to-row-band
+// scaling for a non-constant and a constant (zero-span) sample set.
+func TestRenderAnalogRow(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"varying samples", "~0.1,2.5,3.3,0.4 vout\n"},
+		{"constant samples", "~1,1,1 vout\n"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := Parse(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			b := d.Bounds()
+			dest := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+			gc := draw2dimg.NewGraphicContext(dest)
+			if err := d.RenderGC(gc); err != nil {
+				t.Fatalf("RenderGC: %v", err)
+			}
+		})
+	}
+}
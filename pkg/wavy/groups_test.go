@@ -0,0 +1,127 @@
+package wavy
+
+import (
+	"bytes"
+	"image"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/llgcode/draw2d/draw2dimg"
+)
+
+// TestParseEdge checks parseEdge's "a~>b" syntax, with and without a
+// quoted label.
+func TestParseEdge(t *testing.T) {
+	tests := []struct {
+		rest    string
+		want    edge
+		wantErr bool
+	}{
+		{rest: `a~>b`, want: edge{from: "a", to: "b"}},
+		{rest: `a~>b "setup"`, want: edge{from: "a", to: "b", label: "setup"}},
+		{rest: `a->b`, wantErr: true},
+		{rest: `a~>`, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.rest, func(t *testing.T) {
+			got, err := parseEdge(0, test.rest)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("parseEdge(%q) = %+v, want error", test.rest, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseEdge(%q): %v", test.rest, err)
+			}
+			if got != test.want {
+				t.Errorf("parseEdge(%q) = %+v, want %+v", test.rest, got, test.want)
+			}
+		})
+	}
+}
+
+// TestParseGroupsAndGaps checks that Parse assigns group: ...
+// endgroup: blocks to the rows between them and collects gap: lines
+// into d.gaps.
+func TestParseGroupsAndGaps(t *testing.T) {
+	input := strings.Join([]string{
+		"group: bus",
+		"x<->x a",
+		"x<->x b",
+		"endgroup:",
+		"x<->x c",
+		"gap: 2",
+		"",
+	}, "\n")
+	d, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(d.sigs) != 3 {
+		t.Fatalf("got %d sigs, want 3", len(d.sigs))
+	}
+	if d.sigs[0].group != "bus" || d.sigs[1].group != "bus" {
+		t.Errorf("grouped rows = %q, %q, want both %q", d.sigs[0].group, d.sigs[1].group, "bus")
+	}
+	if d.sigs[2].group != "" {
+		t.Errorf("row after endgroup: has group %q, want none", d.sigs[2].group)
+	}
+	if want := []int{2}; !equalInts(d.gaps, want) {
+		t.Errorf("gaps = %v, want %v", d.gaps, want)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestEdgeResolution renders a diagram whose edge: line refers to a
+// node declared on a signal row, and checks that RenderGC resolves it
+// without logging "unresolved node", then checks that a reference to
+// a node that was never declared does log it.
+func TestEdgeResolution(t *testing.T) {
+	render := func(wvy string) string {
+		t.Helper()
+		d, err := Parse(strings.NewReader(wvy))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		b := d.Bounds()
+		dest := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		gc := draw2dimg.NewGraphicContext(dest)
+
+		var buf bytes.Buffer
+		saved := log.Writer()
+		log.SetOutput(&buf)
+		defer log.SetOutput(saved)
+
+		if err := d.RenderGC(gc); err != nil {
+			t.Fatalf("RenderGC: %v", err)
+		}
+		return buf.String()
+	}
+
+	t.Run("resolved", func(t *testing.T) {
+		out := render("^__ clk . a\nedge: a~>a \"t\"\n")
+		if strings.Contains(out, "unresolved node") {
+			t.Errorf("log output contains \"unresolved node\": %q", out)
+		}
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		out := render("^__ clk . a\nedge: a~>missing \"t\"\n")
+		if !strings.Contains(out, "unresolved node") {
+			t.Errorf("log output = %q, want it to contain \"unresolved node\"", out)
+		}
+	})
+}
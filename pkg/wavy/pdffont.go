@@ -0,0 +1,68 @@
+package wavy
+
+import (
+	_ "embed"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+//go:embed cp1252.map
+var cp1252Map []byte
+
+// pdfFontBase is the font-metrics file base name draw2dpdf expects
+// for the FontData RenderGC registers for text (Name: "goregular",
+// Family: draw2d.FontFamilyMono, Style: draw2d.FontStyleNormal):
+// draw2d.FontFileName appends "m" for FontFamilyMono and "r" for
+// FontStyleNormal.
+const pdfFontBase = "goregularmr"
+
+var (
+	pdfFontDirOnce sync.Once
+	pdfFontDir     string
+	pdfFontDirErr  error
+)
+
+// PDFFontDir returns the directory holding a gofpdf font-metrics
+// file for the embedded goregular font that RenderGC draws text
+// with, generating the file into a temporary directory on first
+// use.
+//
+// draw2dpdf.GraphicContext.SetFontData, unlike the raster and SVG
+// backends, cannot draw text from TrueType bytes alone: it requires
+// a font definition file pre-generated with gofpdf's makefont
+// utility (see its doc comment). Callers rendering a Diagram to PDF
+// must point gofpdf at the directory PDFFontDir returns before
+// calling RenderGC, e.g.:
+//
+//	dir, err := wavy.PDFFontDir()
+//	if err != nil { ... }
+//	pdf := gofpdf.NewCustom(&gofpdf.InitType{..., FontDirStr: dir})
+func PDFFontDir() (string, error) {
+	pdfFontDirOnce.Do(func() {
+		dir, err := ioutil.TempDir("", "wavy-pdffont")
+		if err != nil {
+			pdfFontDirErr = err
+			return
+		}
+		ttf := filepath.Join(dir, pdfFontBase+".ttf")
+		if err := ioutil.WriteFile(ttf, goregular.TTF, 0644); err != nil {
+			pdfFontDirErr = err
+			return
+		}
+		mapFile := filepath.Join(dir, "cp1252.map")
+		if err := ioutil.WriteFile(mapFile, cp1252Map, 0644); err != nil {
+			pdfFontDirErr = err
+			return
+		}
+		if err := gofpdf.MakeFont(ttf, mapFile, dir, nil, true); err != nil {
+			pdfFontDirErr = err
+			return
+		}
+		pdfFontDir = dir
+	})
+	return pdfFontDir, pdfFontDirErr
+}
@@ -0,0 +1,46 @@
+package wavy
+
+import "testing"
+
+// TestFormatBusLabels checks formatBusLabels renders values in each
+// supported radix, defaulting unrecognized radixes to hex.
+func TestFormatBusLabels(t *testing.T) {
+	vals := []int64{0, 10, 255}
+	tests := []struct {
+		radix string
+		want  []string
+	}{
+		{"bin", []string{"0", "1010", "11111111"}},
+		{"oct", []string{"0", "12", "377"}},
+		{"dec", []string{"0", "10", "255"}},
+		{"hex", []string{"0", "A", "FF"}},
+		{"", []string{"0", "A", "FF"}},
+	}
+	for _, test := range tests {
+		t.Run(test.radix, func(t *testing.T) {
+			got := formatBusLabels(vals, test.radix)
+			if !equalStrings(got, test.want) {
+				t.Errorf("formatBusLabels(%v, %q) = %v, want %v", vals, test.radix, got, test.want)
+			}
+		})
+	}
+}
+
+// TestStepSizesForRadix checks that Diagram.step recomputes a bus
+// signal's box width from the current Radix rather than the radix in
+// effect when Parse ran, since a binary label needs much more room
+// than a hex one.
+func TestStepSizesForRadix(t *testing.T) {
+	d := &Diagram{
+		Radix: "hex",
+		sigs: []signal{
+			{name: "data", states: "x<--->x", bus: true, busValues: []int64{0xFF}},
+		},
+	}
+	hexStep := d.step()
+	d.Radix = "bin"
+	binStep := d.step()
+	if binStep <= hexStep {
+		t.Errorf("step() for bin radix = %d, want more room than hex's %d", binStep, hexStep)
+	}
+}
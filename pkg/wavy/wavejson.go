@@ -0,0 +1,172 @@
+package wavy
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// waveJSON mirrors the subset of the WaveDrom WaveJSON schema that
+// wavy understands: a list of named signals, an optional head/foot
+// caption, and config.hscale. Grouped signal rows (nested arrays in
+// "signal") are not supported: ParseWaveJSON returns a decoding
+// error for input that contains them.
+type waveJSON struct {
+	Signal []waveJSONSignal `json:"signal"`
+	Head   *waveJSONText    `json:"head"`
+	Foot   *waveJSONText    `json:"foot"`
+	Config *waveJSONConfig  `json:"config"`
+}
+
+type waveJSONText struct {
+	Text string `json:"text"`
+}
+
+type waveJSONConfig struct {
+	HScale float64 `json:"hscale"`
+}
+
+type waveJSONSignal struct {
+	Name  string   `json:"name"`
+	Wave  string   `json:"wave"`
+	Data  []string `json:"data"`
+	Phase float64  `json:"phase"`
+}
+
+// ParseWaveJSON reads a WaveDrom WaveJSON waveform description from
+// r and converts it into the same Diagram model Parse builds from
+// wvy input.
+func ParseWaveJSON(r io.Reader) (*Diagram, error) {
+	var wj waveJSON
+	if err := json.NewDecoder(r).Decode(&wj); err != nil {
+		return nil, err
+	}
+
+	d := &Diagram{FontSize: 16, Radix: "hex"}
+	text, width := 1, 1
+	add := func(sig signal) {
+		_, w := mult(sig)
+		if w > width {
+			width = w
+		}
+		if t := len(sig.name); t > text {
+			text = t
+		}
+		d.sigs = append(d.sigs, sig)
+	}
+
+	if wj.Head != nil && wj.Head.Text != "" {
+		add(signal{name: wj.Head.Text})
+	}
+	for _, s := range wj.Signal {
+		if s.Name == "" && s.Wave == "" {
+			continue
+		}
+		add(waveToSignal(s))
+	}
+	if wj.Foot != nil && wj.Foot.Text != "" {
+		add(signal{name: wj.Foot.Text})
+	}
+	if wj.Config != nil && wj.Config.HScale > 0 {
+		d.hscale = wj.Config.HScale
+	}
+
+	d.text, d.width = text, width
+	return d, nil
+}
+
+// waveToSignal converts one WaveJSON "signal" entry into wavy's
+// internal signal representation, expanding the wave string into
+// the per-step state characters the transition renderer in
+// RenderGC switches on.
+func waveToSignal(s waveJSONSignal) signal {
+	sig := signal{name: s.Name, phase: s.Phase}
+
+	var states []byte
+	var last byte
+	// pulsing is true while last was produced by a "p"/"P"/"n"/"N"
+	// clock-edge character, so that "." continues the edge train by
+	// toggling the level each step, rather than holding it flat the
+	// way "." does after a plain "h"/"l" level.
+	pulsing := false
+	dataIdx := 0
+	inBox := false
+	for i := 0; i < len(s.Wave); i++ {
+		c := s.Wave[i]
+		switch c {
+		case '.':
+			if len(states) > 0 {
+				if pulsing {
+					if last == '^' {
+						last = '_'
+					} else {
+						last = '^'
+					}
+				}
+				states = append(states, last)
+			}
+			continue
+		case '|':
+			pulsing = false
+			states = append(states, '%')
+			last = '%'
+			continue
+		}
+
+		var out byte
+		pulsing = false
+		switch c {
+		case '0', 'l', 'L':
+			out = '_'
+		case '1', 'h', 'H':
+			out = '^'
+		case 'p', 'P':
+			out = '^'
+			pulsing = true
+		case 'n', 'N':
+			out = '_'
+			pulsing = true
+		case 'x':
+			out = 'x'
+		case 'z':
+			out = 'z'
+		case '=', '2', '3', '4', '5', '6', '7', '8', '9':
+			if inBox {
+				states = append(states, '>')
+			} else if len(states) > 0 && last != 'x' {
+				// The transition renderer only recognizes a box
+				// opening after an "x" or another box's closing
+				// ">", so bridge a plain level into the box via
+				// "x" the same way a literal wvy diagram would.
+				states = append(states, 'x')
+			}
+			states = append(states, '<')
+			inBox = true
+			if dataIdx < len(s.Data) {
+				sig.labels = append(sig.labels, s.Data[dataIdx])
+				dataIdx++
+			}
+			last = '-'
+			states = append(states, last)
+			continue
+		default:
+			out = 'x'
+		}
+		if inBox {
+			states = append(states, '>')
+			if out != 'x' {
+				// Symmetric bridge: the renderer only recognizes a
+				// box closing into "x" or another box's opening
+				// "<".
+				states = append(states, 'x')
+			}
+			inBox = false
+		}
+		states = append(states, out)
+		last = out
+	}
+	if inBox {
+		states = append(states, '>')
+	}
+	sig.states = string(states)
+	return sig
+}
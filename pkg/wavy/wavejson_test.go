@@ -0,0 +1,109 @@
+package wavy
+
+import (
+	"strings"
+	"testing"
+)
+
+// recognizedCombos lists every two-character transition the RenderGC
+// switch in wavy.go handles. Kept in sync with that switch by hand;
+// TestWaveToSignalStates below checks every case against it, to catch the case
+// where waveToSignal drifts out of sync with it.
+var recognizedCombos = map[string]bool{
+	"^^": true, "/^": true, "%^": true, "^%": true,
+	"^_": true,
+	"^\\": true,
+	"__":  true, "\\_": true, "%_": true, "_%": true,
+	"_^":     true,
+	"_/":     true,
+	"><":     true, ">>": true,
+	"^x": true,
+	"_x": true,
+	"_z": true,
+	"x^": true,
+	"x_": true,
+	"xx": true, "<-": true, "->": true, "--": true, "x%": true, "%x": true, "-%": true, "%-": true,
+	">x": true,
+	"x<": true,
+	"zx": true,
+	"xz": true,
+	"zz": true, "z%": true, "%z": true,
+	"%%": true,
+}
+
+// assertOnlyRecognizedCombos fails t if any adjacent pair of
+// characters in states is not one of recognizedCombos.
+func assertOnlyRecognizedCombos(t *testing.T, states string) {
+	t.Helper()
+	chars := strings.Split(states, "")
+	for i := 1; i < len(chars); i++ {
+		combo := chars[i-1] + chars[i]
+		if !recognizedCombos[combo] {
+			t.Errorf("states %q: unrecognized signal pair %d = %q", states, i, combo)
+		}
+	}
+}
+
+func TestWaveToSignalStates(t *testing.T) {
+	tests := []struct {
+		name   string
+		sig    waveJSONSignal
+		states string
+		labels []string
+	}{
+		{
+			name:   "levels",
+			sig:    waveJSONSignal{Wave: "01.hl"},
+			states: "_^^^_",
+		},
+		{
+			name:   "pulse train",
+			sig:    waveJSONSignal{Wave: "p.n."},
+			states: "^__^",
+		},
+		{
+			name:   "gap",
+			sig:    waveJSONSignal{Wave: "01|0"},
+			states: "_^%_",
+		},
+		{
+			name:   "box after x",
+			sig:    waveJSONSignal{Wave: "x=2", Data: []string{"A", "B"}},
+			states: "x<-><->",
+			labels: []string{"A", "B"},
+		},
+		{
+			name: "box adjacent to a plain level needs bridging",
+			// This is the exact repro from the review: a box
+			// opening right after "0" and another box opening
+			// right after a box closes, with no "x" in between.
+			sig:    waveJSONSignal{Wave: "=0==", Data: []string{"A", "B", "C"}},
+			states: "<->x_x<-><->",
+			labels: []string{"A", "B", "C"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sig := waveToSignal(test.sig)
+			if sig.states != test.states {
+				t.Errorf("states = %q, want %q", sig.states, test.states)
+			}
+			assertOnlyRecognizedCombos(t, sig.states)
+			if len(test.labels) != 0 && !equalStrings(sig.labels, test.labels) {
+				t.Errorf("labels = %v, want %v", sig.labels, test.labels)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,848 @@
+// Package wavy parses waveform descriptions and renders them as
+// timing diagrams. Two input formats are understood: wavy's own
+// wvy format, read with Parse, and WaveDrom's WaveJSON format, read
+// with ParseWaveJSON.
+//
+// The wvy format is a plain text file:
+//
+// <chars><space><signal><space><commad-values>
+// <blank lines = half line skip>
+//
+// When commad-values begins with "=", it is a bus value stream
+// rather than a plain label list, e.g. "x<--->x<--->x<--->x name
+// =0x1A,0x2B,0xFF": each value is parsed as an integer and formatted
+// per Diagram.Radix to label the corresponding "<...>" box in chars.
+// A fourth, optional field on a signal line is a node string,
+// aligned one character per position in chars, marking named
+// transition points with a single character, typically a letter
+// ("." marks no point).
+//
+// When chars begins with "~", the row is an analog signal instead
+// of a digital one: the rest of chars is a comma-separated list of
+// floating-point samples, rendered as a piecewise-linear trace
+// scaled to fit the row band and labeled with its min and max
+// values, e.g. "~0.1,2.5,3.3,0.4 vout".
+//
+// Four more line prefixes are recognized in place of a signal:
+//
+//	group: name       start a named group of the following rows
+//	endgroup:         close the currently open group
+//	gap: n            break every row at time step n
+//	edge: a~>b "text" draw a labeled arrow between two named nodes
+//
+// A group draws an indented bracket in the left margin spanning the
+// rows between "group:" and "endgroup:". A gap draws the same break
+// glyph used for "%%" in chars, but across every row at once rather
+// than needing to be repeated in each one. An edge connects the
+// pixel positions of two node letters, wherever they were declared
+// on any two (or the same) rows, once those rows have been laid out.
+//
+// A Diagram's rendered size is the smallest size that can hold the
+// parsed waveform at its current FontSize.
+package wavy
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"io/ioutil"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/llgcode/draw2d"
+	"github.com/llgcode/draw2d/draw2dimg"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// spacer is the vertical multiple of FontSize allotted to each row.
+const spacer = 1.8
+
+// signal holds one parsed row of a Diagram.
+type signal struct {
+	name                  string
+	clk                   bool
+	states                string
+	phase                 float64
+	clkHalfPeriodMinusOne int
+	labels                []string
+
+	// bus and busValues hold a multi-bit signal's raw integer
+	// stream, parsed from a "=v,v,..." commad-values field. Its
+	// labels are (re)formatted from busValues in the Diagram's
+	// Radix whenever the signal is rendered.
+	bus       bool
+	busValues []int64
+
+	// group names the "group:"..."endgroup:" block this row falls
+	// in, or "" if it is not grouped.
+	group string
+
+	// nodes is an optional fourth line field, aligned one
+	// character per position in states, naming transition points
+	// that "edge:" lines can refer to.
+	nodes string
+
+	// analog and samples hold an analog row's raw sample stream,
+	// parsed from a "~v,v,..." chars field. Rendered as a
+	// piecewise-linear trace scaled to fit the row band, rather
+	// than through the digital transition switch in RenderGC.
+	analog  bool
+	samples []float64
+}
+
+// edge is a labeled arrow between two named transition points,
+// parsed from an "edge: a~>b \"text\"" line.
+type edge struct {
+	from, to, label string
+}
+
+// point is a pixel position resolved for a named transition point,
+// recorded while a row is rendered and consumed by edge rendering
+// once every row has been laid out.
+type point struct {
+	x, y float64
+}
+
+// Diagram is a parsed waveform description, ready to be measured
+// and rendered.
+type Diagram struct {
+	sigs []signal
+
+	text, width, groupText int
+
+	// hscale is a multiplier applied to the computed step,
+	// set from WaveJSON's config.hscale; 0 means unset (1x).
+	hscale float64
+
+	gaps  []int
+	edges []edge
+
+	// FontSize is the font size, in points, used both to render
+	// text and to scale the diagram. Parse sets this to 16.
+	FontSize float64
+
+	// Debug, when true, causes vertical gridlines to be rendered
+	// across the diagram.
+	Debug bool
+
+	// Radix controls how bus signal values are formatted: "bin",
+	// "oct", "dec" or "hex". Parse sets this to "hex".
+	Radix string
+}
+
+// parseLine digests a single line of wvy-format input.
+func parseLine(i int, line string) (sig signal, err error) {
+	if len(line) == 0 {
+		return
+	}
+	ts := strings.Split(line, " ")
+	if c := len(ts); c < 2 {
+		return sig, fmt.Errorf("line %d: need two or more fields: got %d", i, c)
+	}
+	if strings.HasPrefix(ts[0], "+") {
+		sig.clk = true
+		ns := strings.Split(ts[0], ",")
+		n := len(ns)
+		if n == 0 || n > 2 {
+			return sig, fmt.Errorf("line %d: clock signal requires number[,phase]: %q", i, line)
+		}
+		sig.clkHalfPeriodMinusOne, _ = strconv.Atoi(ns[0][1:])
+		if n == 2 {
+			sig.phase, err = strconv.ParseFloat(ns[1], 64)
+			if err != nil {
+				return sig, fmt.Errorf("line %d: clock phase parse err: %v", i, err)
+			}
+		}
+	} else if strings.HasPrefix(ts[0], "~") {
+		sig.analog = true
+		for _, t := range strings.Split(ts[0][1:], ",") {
+			v, err := strconv.ParseFloat(strings.TrimSpace(t), 64)
+			if err != nil {
+				return sig, fmt.Errorf("line %d: bad analog sample %q: %v", i, t, err)
+			}
+			sig.samples = append(sig.samples, v)
+		}
+	} else {
+		sig.states = ts[0]
+	}
+	sig.name = ts[1]
+	if len(ts) > 2 {
+		if strings.HasPrefix(ts[2], "=") {
+			sig.bus = true
+			for _, t := range strings.Split(ts[2][1:], ",") {
+				v, err := strconv.ParseInt(strings.TrimSpace(t), 0, 64)
+				if err != nil {
+					return sig, fmt.Errorf("line %d: bad bus value %q: %v", i, t, err)
+				}
+				sig.busValues = append(sig.busValues, v)
+			}
+			sig.labels = formatBusLabels(sig.busValues, "hex")
+		} else {
+			sig.labels = strings.Split(ts[2], ",")
+		}
+	}
+	if len(ts) > 3 {
+		sig.nodes = ts[3]
+	}
+	return sig, nil
+}
+
+// parseEdge parses the remainder of an "edge:" line, e.g.
+// `a~>b "setup"`, into an edge. The label is optional.
+func parseEdge(i int, rest string) (edge, error) {
+	parts := strings.SplitN(rest, " ", 2)
+	nodes := strings.SplitN(parts[0], "~>", 2)
+	if len(nodes) != 2 || nodes[0] == "" || nodes[1] == "" {
+		return edge{}, fmt.Errorf("line %d: bad edge spec %q: want a~>b", i, rest)
+	}
+	e := edge{from: nodes[0], to: nodes[1]}
+	if len(parts) == 2 {
+		e.label = strings.Trim(strings.TrimSpace(parts[1]), `"`)
+	}
+	return e, nil
+}
+
+// formatBusLabels renders vals as strings in the given radix
+// ("bin", "oct", "dec" or "hex"; "hex" is the default for any other
+// value), one label per bus value.
+func formatBusLabels(vals []int64, radix string) []string {
+	base := 16
+	switch radix {
+	case "bin":
+		base = 2
+	case "oct":
+		base = 8
+	case "dec":
+		base = 10
+	}
+	labels := make([]string, len(vals))
+	for i, v := range vals {
+		s := strconv.FormatInt(v, base)
+		if base == 16 {
+			s = strings.ToUpper(s)
+		}
+		labels[i] = s
+	}
+	return labels
+}
+
+// mult determines the maximum size of a rendered item (step) and the
+// total number of steps required to render the wave. This is the
+// maximum of the length of a label, or the minimum distance between
+// "<" and ">", or the clk period width.
+func mult(sig signal) (step, width int) {
+	if sig.name == "" {
+		return 0, 0
+	}
+	if sig.analog {
+		return 0, len(sig.samples)
+	}
+	width = len(sig.states)
+	if width == 0 {
+		step = 2 * (1 + sig.clkHalfPeriodMinusOne)
+		return
+	}
+	if len(sig.labels) != 0 {
+		i := 0
+		for _, w := range sig.labels {
+			for ; i < len(sig.states) && sig.states[i] != '<'; i++ {
+			}
+			found := i < len(sig.states) && sig.states[i] == '<'
+			if !found {
+				break
+			}
+			from := i
+			for ; i < len(sig.states) && sig.states[i] != '>'; i++ {
+			}
+			if i != len(sig.states) {
+				i++
+			}
+			c := 3 + len(w)
+			d := 1
+			for c > d*(i-from) {
+				d++
+			}
+			if d > step {
+				step = d
+			}
+		}
+	}
+	return
+}
+
+// Parse reads a wvy-format waveform description from r.
+func Parse(r io.Reader) (*Diagram, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Diagram{FontSize: 16, Radix: "hex"}
+	text, width, groupText := 1, 1, 0
+	var curGroup string
+	for i, line := range strings.Split(string(b), "\n") {
+		if i == 0 && line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "group:"):
+			curGroup = strings.TrimSpace(strings.TrimPrefix(line, "group:"))
+			if l := len(curGroup); l > groupText {
+				groupText = l
+			}
+			continue
+		case strings.HasPrefix(line, "endgroup:"):
+			curGroup = ""
+			continue
+		case strings.HasPrefix(line, "gap:"):
+			n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "gap:")))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad gap column: %v", i, err)
+			}
+			d.gaps = append(d.gaps, n)
+			continue
+		case strings.HasPrefix(line, "edge:"):
+			e, err := parseEdge(i, strings.TrimSpace(strings.TrimPrefix(line, "edge:")))
+			if err != nil {
+				return nil, err
+			}
+			d.edges = append(d.edges, e)
+			continue
+		}
+		sig, err := parseLine(i, line)
+		if err != nil {
+			return nil, err
+		}
+		sig.group = curGroup
+		_, w := mult(sig)
+		if w > width {
+			width = w
+		}
+		if t := len(sig.name); t > text {
+			text = t
+		}
+		d.sigs = append(d.sigs, sig)
+	}
+	if len(d.sigs) > 1 && d.sigs[len(d.sigs)-1].name == "" {
+		d.sigs = d.sigs[:len(d.sigs)-1]
+	}
+	d.text, d.width, d.groupText = text, width, groupText
+	return d, nil
+}
+
+// groupMargin returns the extra left margin reserved for group
+// brackets and their name labels, or 0 if d has no groups.
+func (d *Diagram) groupMargin() float64 {
+	if d.groupText == 0 {
+		return 0
+	}
+	return d.FontSize * (1.2 + 0.6*float64(d.groupText))
+}
+
+// step returns the box size used for multi-character labels and clk
+// periods, recomputed from d.sigs using the current Radix. Bus
+// labels are re-formatted here rather than cached from Parse, since
+// a caller is free to change Radix after Parse returns, and a
+// binary label needs much more room than a hex one.
+func (d *Diagram) step() int {
+	step := 1
+	for _, sig := range d.sigs {
+		if sig.bus {
+			sig.labels = formatBusLabels(sig.busValues, d.Radix)
+		}
+		if n, _ := mult(sig); n > step {
+			step = n
+		}
+	}
+	if d.hscale > 0 {
+		step = int(math.Ceil(float64(step) * d.hscale))
+	}
+	return step
+}
+
+// Bounds returns the pixel dimensions required to render d at its
+// current FontSize and Radix.
+func (d *Diagram) Bounds() image.Rectangle {
+	right := d.groupMargin() + float64(d.text)*d.FontSize
+	wide := right + d.FontSize*0.5*float64(d.step()*(-1+d.width))
+	high := math.Ceil(spacer * d.FontSize * float64(2+len(d.sigs)))
+	return image.Rect(0, 0, int(wide), int(high))
+}
+
+// Render draws d onto dest, which must be large enough to hold
+// d.Bounds().
+func (d *Diagram) Render(dest draw.Image) error {
+	rgba, ok := dest.(*image.RGBA)
+	if !ok {
+		return fmt.Errorf("wavy: Render requires a *image.RGBA destination, got %T", dest)
+	}
+	return d.RenderGC(draw2dimg.NewGraphicContext(rgba))
+}
+
+// RenderGC draws d using gc, an arbitrary draw2d graphic context.
+// This is the hook embedders reach for when they want a backend
+// other than the raster one Render uses, e.g. one of draw2dsvg or
+// draw2dpdf. A draw2dpdf.GraphicContext additionally needs its
+// gofpdf.Fpdf pointed at the directory returned by PDFFontDir
+// before RenderGC is called; see its doc comment.
+func (d *Diagram) RenderGC(gc draw2d.GraphicContext) error {
+	b := d.Bounds()
+	wide, high := float64(b.Dx()), float64(b.Dy())
+
+	sigs := make([]signal, len(d.sigs))
+	copy(sigs, d.sigs)
+
+	for i, sig := range sigs {
+		if sig.bus {
+			sigs[i].labels = formatBusLabels(sig.busValues, d.Radix)
+		}
+	}
+
+	gc.SetFillColor(color.White)
+	gc.SetStrokeColor(color.Black)
+
+	gc.MoveTo(0, 0)
+	gc.LineTo(wide, 0)
+	gc.LineTo(wide, high)
+	gc.LineTo(0, high)
+	gc.Close()
+	gc.Fill()
+
+	mp := "^_"
+	for i, sig := range sigs {
+		if !sig.clk {
+			continue
+		}
+		var parts []string
+		outer := 0
+		for k := d.width + 2*(sig.clkHalfPeriodMinusOne+1); k > 0; k-- {
+			c := mp[outer : outer+1]
+			k++
+			for j := 0; k > 0; j++ {
+				k--
+				parts = append(parts, c)
+				if j == sig.clkHalfPeriodMinusOne {
+					break
+				}
+			}
+			outer = 1 - outer
+		}
+		sigs[i].states = strings.Join(parts, "")
+	}
+
+	full := 0.5 * d.FontSize * float64(d.step())
+
+	left := d.groupMargin()
+
+	if d.Debug {
+		right := left + float64(d.text)*d.FontSize
+		for i := 0; i < d.width; i++ {
+			gc.MoveTo(right+full*(0.5+float64(i)), 0)
+			gc.LineTo(right+full*(0.5+float64(i)), high)
+			gc.Stroke()
+		}
+	}
+
+	font, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		return fmt.Errorf("wavy: %v", err)
+	}
+	fondata := draw2d.FontData{Name: "goregular", Family: draw2d.FontFamilyMono, Style: draw2d.FontStyleNormal}
+	draw2d.RegisterFont(
+		fondata,
+		font,
+	)
+	gc.SetFontData(fondata)
+
+	right := left + float64(d.text)*d.FontSize
+
+	nodePositions := make(map[string]point)
+
+	for i, sig := range sigs {
+		if sig.name == "" {
+			continue
+		}
+
+		phase := sig.phase * full * float64(1+sig.clkHalfPeriodMinusOne)
+		half := 0.5 * full
+		demi := 0.5 * d.FontSize
+		bot := d.FontSize * (0.5 + float64(2+i)) * spacer
+		top := bot - d.FontSize*spacer
+		mid := 0.5 * (bot + top)
+
+		gc.SetFillColor(color.RGBA{0xcc, 0xcc, 0xcc, 0xff})
+		gc.MoveTo(0, bot-1)
+		gc.LineTo(wide, bot-1)
+		gc.LineTo(wide, top+1)
+		gc.LineTo(0, top+1)
+		gc.Close()
+		gc.Fill()
+
+		if sig.analog {
+			drawAnalog(gc, sig.samples, right, full, top, bot, d.FontSize)
+			for _, col := range d.gaps {
+				start := right + full*(-1+float64(col))
+				drawBreak(gc, start, start+full, mid, d.FontSize, half)
+			}
+
+			gc.SetFillColor(color.White)
+			gc.MoveTo(0, bot-1)
+			gc.LineTo(right, bot-1)
+			gc.LineTo(right, top+1)
+			gc.LineTo(0, top+1)
+			gc.Close()
+			gc.Fill()
+
+			gc.SetFillColor(image.Black)
+			gc.SetFontSize(d.FontSize)
+
+			_, _, w, _ := gc.GetStringBounds(sig.name)
+			gc.FillStringAt(sig.name, right-(d.FontSize*0.5+w), bot-0.4*d.FontSize)
+			continue
+		}
+
+		var oldC string
+		var labNo int
+		var lastStart, lastEnd float64
+		soFar := make(map[string]bool)
+		for i, c := range strings.Split(sig.states, "") {
+			start := right + full*(-1+float64(i)) - phase
+			if i < len(sig.nodes) {
+				if n := sig.nodes[i]; n != '.' && n != ' ' {
+					nodePositions[string(n)] = point{start, mid}
+				}
+			}
+			if oldC == "" {
+				oldC = c
+				continue
+			}
+			showLabel := false
+			nextStart := lastStart
+			combo := oldC + c
+			gc.SetStrokeColor(color.RGBA{0x00, 0x00, 0xff, 0xff})
+			switch combo {
+			case "^^", "/^", "%^", "^%":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "^_":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+half, mid-demi)
+				gc.LineTo(start+half, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.Stroke()
+			case "^\\":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+half*0.7, mid-demi)
+				gc.LineTo(start+half*1.3, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.Stroke()
+			case "__", "\\_", "%_", "_%":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.Stroke()
+			case "_^":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+half, mid+demi)
+				gc.LineTo(start+half, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "_/":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+half*0.7, mid+demi)
+				gc.LineTo(start+half*1.3, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "><", ">>":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+0.7*half, mid-demi)
+				gc.LineTo(start+1.3*half, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				lastEnd = start + half
+				showLabel = combo != ">>"
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+0.7*half, mid+demi)
+				gc.LineTo(start+1.3*half, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+				nextStart = start + half
+			case "^x":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.MoveTo(start+half, mid-demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.Stroke()
+			case "_x":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.MoveTo(start+half, mid+demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "_z":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+half, mid+demi)
+				gc.Stroke()
+				gc.SetLineDash([]float64{d.FontSize * 0.3, d.FontSize * 0.2}, 0)
+				gc.MoveTo(start+half, mid+demi)
+				gc.LineTo(start+half, mid)
+				gc.LineTo(start+full, mid)
+				gc.Stroke()
+				gc.SetLineDash(nil, 0)
+			case "x^":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+half, mid-demi)
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "x_":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+half, mid+demi)
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.Stroke()
+			case "xx", "<-", "->", "--", "x%", "%x", "-%", "%-":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case ">x":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+0.7*half, mid-demi)
+				gc.LineTo(start+half, mid)
+				gc.LineTo(start+0.7*half, mid+demi)
+				gc.LineTo(start, mid+demi)
+				lastEnd = start + half
+				showLabel = true
+				gc.Stroke()
+				gc.MoveTo(start+full, mid+demi)
+				gc.LineTo(start+half, mid)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "x<":
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+half, mid)
+				gc.LineTo(start, mid-demi)
+				gc.Stroke()
+				gc.MoveTo(start+full, mid-demi)
+				gc.LineTo(start+1.1*half, mid-demi)
+				gc.LineTo(start+half, mid)
+				gc.LineTo(start+1.1*half, mid+demi)
+				gc.LineTo(start+full, mid+demi)
+				gc.Stroke()
+				nextStart = start + half
+			case "zx":
+				gc.SetLineDash([]float64{d.FontSize * 0.3, d.FontSize * 0.2}, 0)
+				gc.MoveTo(start, mid)
+				gc.LineTo(start+half, mid)
+				gc.Stroke()
+				gc.SetLineDash(nil, 0)
+				gc.MoveTo(start+full, mid+demi)
+				gc.LineTo(start+half, mid)
+				gc.LineTo(start+full, mid-demi)
+				gc.Stroke()
+			case "xz":
+				gc.MoveTo(start, mid-demi)
+				gc.LineTo(start+half, mid)
+				gc.MoveTo(start, mid+demi)
+				gc.LineTo(start+half, mid)
+				gc.Stroke()
+				gc.SetLineDash([]float64{d.FontSize * 0.3, d.FontSize * 0.2}, 0)
+				gc.MoveTo(start+half, mid)
+				gc.LineTo(start+full, mid)
+				gc.Stroke()
+				gc.SetLineDash(nil, 0)
+			case "zz", "z%", "%z":
+				gc.SetLineDash([]float64{d.FontSize * 0.3, d.FontSize * 0.2}, 0)
+				gc.MoveTo(start, mid)
+				gc.LineTo(start+full, mid)
+				gc.Stroke()
+				gc.SetLineDash(nil, 0)
+			case "%%":
+				drawBreak(gc, start, start+full, mid, d.FontSize, half)
+			default:
+				if !soFar[combo] {
+					soFar[combo] = true
+					log.Printf("unrecognized signal pair %q:%d = %q", sig.name, i, combo)
+				}
+			}
+			if showLabel && labNo < len(sig.labels) {
+				lab := sig.labels[labNo]
+				gc.SetFillColor(image.Black)
+				gc.SetFontSize(0.8 * d.FontSize)
+
+				_, _, w, _ := gc.GetStringBounds(lab)
+				gc.FillStringAt(lab, 0.5*(lastStart+lastEnd-w), bot-0.5*d.FontSize)
+				labNo++
+			}
+			lastStart = nextStart
+			oldC = c
+		}
+
+		for _, col := range d.gaps {
+			start := right + full*(-1+float64(col))
+			drawBreak(gc, start, start+full, mid, d.FontSize, half)
+		}
+
+		gc.SetFillColor(color.White)
+		gc.MoveTo(0, bot-1)
+		gc.LineTo(right, bot-1)
+		gc.LineTo(right, top+1)
+		gc.LineTo(0, top+1)
+		gc.Close()
+		gc.Fill()
+
+		gc.SetFillColor(image.Black)
+		gc.SetFontSize(d.FontSize)
+
+		_, _, w, _ := gc.GetStringBounds(sig.name)
+		gc.FillStringAt(sig.name, right-(d.FontSize*0.5+w), bot-0.4*d.FontSize)
+	}
+
+	drawGroups(gc, sigs, left, d.FontSize)
+	drawEdges(gc, d.edges, nodePositions, d.FontSize)
+
+	return nil
+}
+
+// drawBreak masks out a "%%"-style zigzag break between start and
+// stop, centered vertically on mid. It is used both for an inline
+// "%%" transition in a signal's own states and for a "gap:" line,
+// which applies the same break across every row at once.
+func drawBreak(gc draw2d.GraphicContext, start, stop, mid, fontSize, half float64) {
+	vert := fontSize * spacer * 0.5
+	gc.SetFillColor(image.White)
+	gc.MoveTo(start, mid-vert)
+	gc.LineTo(start-half*0.2, mid+vert/10)
+	gc.LineTo(start+half*0.2, mid+vert/10)
+	gc.LineTo(start, mid+vert)
+	gc.LineTo(stop, mid+vert)
+	gc.LineTo(stop+half*0.2, mid-vert/10)
+	gc.LineTo(stop-half*0.2, mid-vert/10)
+	gc.LineTo(stop, mid-vert)
+	gc.Close()
+	gc.Fill()
+}
+
+// drawAnalog draws samples as a piecewise-linear trace across the
+// row band [top, bot], scaled so its minimum and maximum values land
+// on bot-1 and top+1, and labeled with those two values. Each sample
+// occupies one column of width full, starting at right.
+func drawAnalog(gc draw2d.GraphicContext, samples []float64, right, full, top, bot, fontSize float64) {
+	if len(samples) == 0 {
+		return
+	}
+	lo, hi := samples[0], samples[0]
+	for _, v := range samples[1:] {
+		if v < lo {
+			lo = v
+		}
+		if v > hi {
+			hi = v
+		}
+	}
+	span := hi - lo
+	if span == 0 {
+		span = 1
+	}
+	y := func(v float64) float64 {
+		return (bot - 1) - (v-lo)/span*((bot-1)-(top+1))
+	}
+
+	gc.SetStrokeColor(color.RGBA{0x00, 0x99, 0x00, 0xff})
+	gc.MoveTo(right, y(samples[0]))
+	for i, v := range samples {
+		gc.LineTo(right+full*float64(i), y(v))
+	}
+	gc.Stroke()
+
+	gc.SetFillColor(image.Black)
+	gc.SetFontSize(0.6 * fontSize)
+	gc.FillStringAt(fmt.Sprintf("%g", hi), right+2, top+0.9*fontSize)
+	gc.FillStringAt(fmt.Sprintf("%g", lo), right+2, bot-0.2*fontSize)
+}
+
+// drawGroups draws a bracket in the left margin for each contiguous
+// run of rows sharing a non-empty group name, labeled with that
+// name.
+func drawGroups(gc draw2d.GraphicContext, sigs []signal, left, fontSize float64) {
+	if left == 0 {
+		return
+	}
+	gx := left - 4
+	for i := 0; i < len(sigs); {
+		g := sigs[i].group
+		if g == "" {
+			i++
+			continue
+		}
+		j := i
+		for j < len(sigs) && sigs[j].group == g {
+			j++
+		}
+		top := fontSize*(0.5+float64(2+i))*spacer - fontSize*spacer
+		bot := fontSize * (0.5 + float64(2+j-1)) * spacer
+		gc.SetStrokeColor(color.Black)
+		gc.MoveTo(gx+4, top)
+		gc.LineTo(gx, top)
+		gc.LineTo(gx, bot)
+		gc.LineTo(gx+4, bot)
+		gc.Stroke()
+
+		gc.SetFillColor(image.Black)
+		gc.SetFontSize(0.7 * fontSize)
+		gc.FillStringAt(g, 2, 0.5*(top+bot)+0.25*fontSize)
+		i = j
+	}
+}
+
+// drawEdges draws a labeled arrow for each edge whose endpoints were
+// recorded in positions while the rows were rendered. Edges
+// referring to a node letter that never appeared in any row's nodes
+// field are logged and skipped.
+func drawEdges(gc draw2d.GraphicContext, edges []edge, positions map[string]point, fontSize float64) {
+	for _, e := range edges {
+		from, ok1 := positions[e.from]
+		to, ok2 := positions[e.to]
+		if !ok1 || !ok2 {
+			log.Printf("edge %s~>%s: unresolved node", e.from, e.to)
+			continue
+		}
+		gc.SetStrokeColor(color.RGBA{0xcc, 0x00, 0x00, 0xff})
+		gc.MoveTo(from.x, from.y)
+		gc.LineTo(to.x, to.y)
+		gc.Stroke()
+		drawArrowhead(gc, from, to)
+
+		if e.label != "" {
+			gc.SetFillColor(image.Black)
+			gc.SetFontSize(0.7 * fontSize)
+			_, _, w, _ := gc.GetStringBounds(e.label)
+			gc.FillStringAt(e.label, 0.5*(from.x+to.x-w), 0.5*(from.y+to.y)-4)
+		}
+	}
+}
+
+// drawArrowhead fills a small triangle at to, pointing away from
+// from.
+func drawArrowhead(gc draw2d.GraphicContext, from, to point) {
+	dx, dy := to.x-from.x, to.y-from.y
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return
+	}
+	ux, uy := dx/length, dy/length
+	px, py := -uy, ux
+	const size = 5.0
+	gc.SetFillColor(color.RGBA{0xcc, 0x00, 0x00, 0xff})
+	gc.MoveTo(to.x, to.y)
+	gc.LineTo(to.x-size*ux+size*0.5*px, to.y-size*uy+size*0.5*py)
+	gc.LineTo(to.x-size*ux-size*0.5*px, to.y-size*uy-size*0.5*py)
+	gc.Close()
+	gc.Fill()
+}
@@ -0,0 +1,85 @@
+package wavy
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseLineFields checks parseLine's handling of the first three
+// space-separated fields a wvy signal line can carry: states, name,
+// and labels (plain or bus). The fourth, nodes, is checked by
+// TestParseNodesField.
+func TestParseLineFields(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want signal
+	}{
+		{
+			name: "states and name only",
+			line: "^__ clk",
+			want: signal{name: "clk", states: "^__"},
+		},
+		{
+			name: "plain labels",
+			line: "x<->x<->x data idle,run",
+			want: signal{name: "data", states: "x<->x<->x", labels: []string{"idle", "run"}},
+		},
+		{
+			name: "bus values default to hex labels",
+			line: "x<->x<->x data =0x1A,0xFF",
+			want: signal{
+				name:      "data",
+				states:    "x<->x<->x",
+				bus:       true,
+				busValues: []int64{0x1A, 0xFF},
+				labels:    []string{"1A", "FF"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseLine(0, test.line)
+			if err != nil {
+				t.Fatalf("parseLine: %v", err)
+			}
+			if got.name != test.want.name || got.states != test.want.states || got.bus != test.want.bus {
+				t.Errorf("parseLine(%q) = %+v, want %+v", test.line, got, test.want)
+			}
+			if !equalStrings(got.labels, test.want.labels) {
+				t.Errorf("labels = %v, want %v", got.labels, test.want.labels)
+			}
+			if len(test.want.busValues) != 0 && !equalInt64s(got.busValues, test.want.busValues) {
+				t.Errorf("busValues = %v, want %v", got.busValues, test.want.busValues)
+			}
+		})
+	}
+}
+
+func equalInt64s(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestParseNodesField checks that a signal line's optional fourth
+// field is carried through Parse into sig.nodes, aligned one
+// character per states position.
+func TestParseNodesField(t *testing.T) {
+	d, err := Parse(strings.NewReader("^__ clk . a\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if len(d.sigs) != 1 {
+		t.Fatalf("got %d sigs, want 1", len(d.sigs))
+	}
+	if got, want := d.sigs[0].nodes, "a"; got != want {
+		t.Errorf("nodes = %q, want %q", got, want)
+	}
+}